@@ -1,52 +1,259 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
+	"crypto/subtle"
+	"encoding/hex"
 	"flag"
 	"fmt"
 	"hash"
 	"io"
+	"io/fs"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/skeeto/pengyhash"
 )
 
-func run(h hash.Hash, filename string) error {
+// algo describes one of the hash variants the CLI can drive.
+type algo struct {
+	tag string // BSD-style --tag label, e.g. "PENGYHASH256"
+	new func(seed uint64) hash.Hash
+}
+
+var algos = map[string]algo{
+	"pengy256": {"PENGYHASH256", pengyhash.New},
+	"pengy64":  {"PENGYHASH64", func(seed uint64) hash.Hash { return pengyhash.New64(uint32(seed)) }},
+}
+
+func sumFile(h hash.Hash, filename string) ([]byte, error) {
+	h.Reset()
+
 	var r io.Reader
 	if filename == "-" {
 		r = os.Stdin
 	} else {
 		f, err := os.Open(filename)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		defer f.Close()
 		r = f
 	}
 
-	_, err := io.Copy(h, r)
+	if _, err := io.Copy(h, r); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+func printSum(w io.Writer, tag bool, algoTag, filename string, sum []byte, term byte) {
+	if tag {
+		fmt.Fprintf(w, "%s (%s) = %x%c", algoTag, filename, sum, term)
+	} else {
+		fmt.Fprintf(w, "%x  %s%c", sum, filename, term)
+	}
+}
+
+// collectFiles expands args into a flat list of files, walking any
+// directories when recursive is set.
+func collectFiles(args []string, recursive bool) ([]string, error) {
+	var files []string
+	for _, a := range args {
+		if a == "-" {
+			files = append(files, a)
+			continue
+		}
+
+		info, err := os.Stat(a)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, a)
+			continue
+		}
+		if !recursive {
+			return nil, fmt.Errorf("%s: is a directory", a)
+		}
+		err = filepath.WalkDir(a, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() {
+				files = append(files, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+func runSum(a algo, seed uint64, tag, recursive bool, term byte, args []string) int {
+	files, err := collectFiles(args, recursive)
 	if err != nil {
-		return err
+		fmt.Fprintf(os.Stderr, "pengyhash256: %s\n", err)
+		return 1
 	}
-	fmt.Printf("%02x  %s\n", h.Sum(nil), filename)
 
-	return nil
+	h := a.new(seed)
+	status := 0
+	for _, filename := range files {
+		sum, err := sumFile(h, filename)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "pengyhash256: %s\n", err)
+			status = 1
+			continue
+		}
+		printSum(os.Stdout, tag, a.tag, filename, sum, term)
+	}
+	return status
 }
 
-func main() {
-	seed := flag.Uint64("seed", 1, "hash function seed")
-	flag.Parse()
+// checkEntry is one parsed record from a -c checksum listing.
+type checkEntry struct {
+	sum      []byte
+	filename string
+}
 
-	args := flag.Args()
-	if len(args) == 0 {
-		args = []string{"-"}
+// parseCheckLine accepts both the BSD --tag format, "TAG (file) = hex", and
+// the plain "hex  file" format, with or without the GNU "*file" binary-mode
+// marker.
+func parseCheckLine(line string) (checkEntry, error) {
+	if open := strings.Index(line, " ("); open > 0 {
+		if close := strings.LastIndex(line, ") = "); close > open {
+			sum, err := hex.DecodeString(line[close+4:])
+			if err != nil {
+				return checkEntry{}, fmt.Errorf("malformed checksum line: %q", line)
+			}
+			return checkEntry{sum: sum, filename: line[open+2 : close]}, nil
+		}
+	}
+
+	sp := strings.IndexByte(line, ' ')
+	if sp < 0 {
+		return checkEntry{}, fmt.Errorf("malformed checksum line: %q", line)
+	}
+	sum, err := hex.DecodeString(line[:sp])
+	if err != nil {
+		return checkEntry{}, fmt.Errorf("malformed checksum line: %q", line)
+	}
+	filename := strings.TrimPrefix(strings.TrimPrefix(line[sp+1:], " "), "*")
+	return checkEntry{sum: sum, filename: filename}, nil
+}
+
+func parseCheckFile(r io.Reader, term byte) ([]checkEntry, error) {
+	sc := bufio.NewScanner(r)
+	sc.Split(scanTerminated(term))
+
+	var entries []checkEntry
+	for sc.Scan() {
+		line := sc.Text()
+		if line == "" {
+			continue
+		}
+		entry, err := parseCheckLine(line)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
 	}
+	return entries, sc.Err()
+}
 
-	for _, filename := range args {
-		err := run(pengyhash.New(*seed), filename)
+// scanTerminated is a bufio.SplitFunc that splits records on term, matching
+// bufio.ScanLines for the default '\n' and supporting the -z/NUL convention.
+func scanTerminated(term byte) bufio.SplitFunc {
+	if term == '\n' {
+		return bufio.ScanLines
+	}
+	return func(data []byte, atEOF bool) (advance int, token []byte, err error) {
+		if atEOF && len(data) == 0 {
+			return 0, nil, nil
+		}
+		if i := bytes.IndexByte(data, term); i >= 0 {
+			return i + 1, data[:i], nil
+		}
+		if atEOF {
+			return len(data), data, nil
+		}
+		return 0, nil, nil
+	}
+}
+
+func runCheck(a algo, seed uint64, term byte, checkFile string) int {
+	var r io.Reader
+	if checkFile == "-" {
+		r = os.Stdin
+	} else {
+		f, err := os.Open(checkFile)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "pengyhash256: %s\n", err)
-			os.Exit(1)
+			return 1
 		}
+		defer f.Close()
+		r = f
+	}
+
+	entries, err := parseCheckFile(r, term)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "pengyhash256: %s\n", err)
+		return 1
 	}
 
+	h := a.new(seed)
+	status := 0
+	for _, entry := range entries {
+		got, err := sumFile(h, entry.filename)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "pengyhash256: %s: %s\n", entry.filename, err)
+			status = 1
+			continue
+		}
+		if subtle.ConstantTimeCompare(got, entry.sum) == 1 {
+			fmt.Printf("%s: OK\n", entry.filename)
+		} else {
+			fmt.Printf("%s: FAILED\n", entry.filename)
+			status = 1
+		}
+	}
+	return status
+}
+
+func main() {
+	seed := flag.Uint64("seed", 1, "hash function seed")
+	algoName := flag.String("algo", "pengy256", "hash algorithm: pengy256 or pengy64 (pengy64 buffers the entire input in memory; unsuitable for large files)")
+	check := flag.String("c", "", "read a checksum listing from FILE and verify it")
+	tag := flag.Bool("tag", false, "emit BSD-style checksum lines")
+	zero := flag.Bool("z", false, "end each line with NUL, not newline")
+	var recursive bool
+	flag.BoolVar(&recursive, "r", false, "hash files in directories recursively")
+	flag.BoolVar(&recursive, "recursive", false, "hash files in directories recursively")
+	flag.Parse()
+
+	a, ok := algos[*algoName]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "pengyhash256: unknown algorithm %q\n", *algoName)
+		os.Exit(1)
+	}
+
+	term := byte('\n')
+	if *zero {
+		term = 0
+	}
+
+	if *check != "" {
+		os.Exit(runCheck(a, *seed, term, *check))
+	}
+
+	args := flag.Args()
+	if len(args) == 0 {
+		args = []string{"-"}
+	}
+	os.Exit(runSum(a, *seed, *tag, recursive, term, args))
 }
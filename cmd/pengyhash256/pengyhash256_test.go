@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseCheckLineBSD(t *testing.T) {
+	line := "PENGYHASH256 (foo.txt) = deadbeef"
+	got, err := parseCheckLine(line)
+	if err != nil {
+		t.Fatalf("parseCheckLine(%q): %v", line, err)
+	}
+	if got.filename != "foo.txt" {
+		t.Errorf("filename, got %q, want %q", got.filename, "foo.txt")
+	}
+	if !bytes.Equal(got.sum, []byte{0xde, 0xad, 0xbe, 0xef}) {
+		t.Errorf("sum, got %x, want deadbeef", got.sum)
+	}
+}
+
+func TestParseCheckLineGNU(t *testing.T) {
+	cases := []struct {
+		line, filename string
+	}{
+		{"deadbeef  foo.txt", "foo.txt"},
+		{"deadbeef *foo.txt", "foo.txt"},
+	}
+	for _, c := range cases {
+		got, err := parseCheckLine(c.line)
+		if err != nil {
+			t.Fatalf("parseCheckLine(%q): %v", c.line, err)
+		}
+		if got.filename != c.filename {
+			t.Errorf("parseCheckLine(%q) filename, got %q, want %q", c.line, got.filename, c.filename)
+		}
+		if !bytes.Equal(got.sum, []byte{0xde, 0xad, 0xbe, 0xef}) {
+			t.Errorf("parseCheckLine(%q) sum, got %x, want deadbeef", c.line, got.sum)
+		}
+	}
+}
+
+func TestParseCheckLineMalformed(t *testing.T) {
+	cases := []string{
+		"",
+		"nohexhere",
+		"zzzz  foo.txt",
+	}
+	for _, line := range cases {
+		if _, err := parseCheckLine(line); err == nil {
+			t.Errorf("parseCheckLine(%q), got nil error, want error", line)
+		}
+	}
+}
+
+func TestScanTerminatedNewline(t *testing.T) {
+	sc := bufio.NewScanner(strings.NewReader("a\nb\nc"))
+	sc.Split(scanTerminated('\n'))
+
+	var got []string
+	for sc.Scan() {
+		got = append(got, sc.Text())
+	}
+	want := []string{"a", "b", "c"}
+	if len(got) != len(want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("token %d, got %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestScanTerminatedNUL(t *testing.T) {
+	sc := bufio.NewScanner(strings.NewReader("a\x00b\x00c"))
+	sc.Split(scanTerminated(0))
+
+	var got []string
+	for sc.Scan() {
+		got = append(got, sc.Text())
+	}
+	want := []string{"a", "b", "c"}
+	if len(got) != len(want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("token %d, got %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestScanTerminatedNULNoTrailingTerm(t *testing.T) {
+	// A final record with no trailing terminator is still returned.
+	sc := bufio.NewScanner(strings.NewReader("a\x00b"))
+	sc.Split(scanTerminated(0))
+
+	var got []string
+	for sc.Scan() {
+		got = append(got, sc.Text())
+	}
+	want := []string{"a", "b"}
+	if len(got) != len(want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestParseCheckFile(t *testing.T) {
+	const listing = "deadbeef  foo.txt\ncafebabe *bar.txt\n"
+	entries, err := parseCheckFile(strings.NewReader(listing), '\n')
+	if err != nil {
+		t.Fatalf("parseCheckFile: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(entries))
+	}
+	if entries[0].filename != "foo.txt" || entries[1].filename != "bar.txt" {
+		t.Errorf("got filenames %q, %q", entries[0].filename, entries[1].filename)
+	}
+}
+
+func TestCollectFiles(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("a"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	sub := filepath.Join(dir, "sub")
+	if err := os.Mkdir(sub, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(sub, "b.txt"), []byte("b"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := collectFiles([]string{dir}, false); err == nil {
+		t.Error("collectFiles on a directory without recursive, got nil error, want error")
+	}
+
+	got, err := collectFiles([]string{dir}, true)
+	if err != nil {
+		t.Fatalf("collectFiles: %v", err)
+	}
+	if len(got) != 2 {
+		t.Errorf("got %d files, want 2: %v", len(got), got)
+	}
+}
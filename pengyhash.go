@@ -1,6 +1,8 @@
-// Package pengyhash implements two variants of the pengyhash hash.
-// One variant is the original, non-incremental hash. The other is an
-// incremental, 256-bit hash. Both variants are non-cryptographic.
+// Package pengyhash implements several variants of the pengyhash hash: the
+// original, non-incremental hash; an incremental 256-bit hash; an
+// incremental 64-bit hash matching the original's output; and a parallel,
+// chunked variant of the 256-bit hash for large inputs. All variants are
+// non-cryptographic.
 package pengyhash
 
 import (
@@ -16,6 +18,20 @@ const Size = 32
 // BlockSize is the internal block size of pengyhash256 in bytes.
 const BlockSize = 32
 
+// magic256, magic64, and magicParallel identify the marshaled state of
+// hash256, hash64, and hashParallel respectively, following the convention
+// used by crypto/sha256: a short, version-tagged prefix so that
+// UnmarshalBinary can reject data from an incompatible variant or a future
+// incompatible layout instead of silently misreading it.
+const (
+	magic256      = "pgy2\x01"
+	magic64       = "pgy1\x01"
+	magicParallel = "pgy4\x01"
+
+	marshaledSize256 = len(magic256) + 32 + 32 + 8 + 8 + 1
+	marshaledSize64  = len(magic64) + 4 + 8
+)
+
 type hash256 struct {
 	block [32]byte
 	s     [4]uint64
@@ -25,9 +41,9 @@ type hash256 struct {
 }
 
 // New returns a new, seeded hash.Hash computing an incremental variant of
-// pengyhash with a 256-bit digest. Also implements encoding.BinaryMarshaler
-// and encoding.BinaryUnmarshaler to marshal and unmarshal the internal state
-// of the hash.
+// pengyhash with a 256-bit digest. Also implements encoding.BinaryMarshaler,
+// encoding.BinaryAppender, and encoding.BinaryUnmarshaler to marshal and
+// unmarshal the internal state of the hash.
 func New(seed uint64) hash.Hash {
 	var h hash256
 	h.seed = seed
@@ -48,23 +64,6 @@ func (h *hash256) Reset() {
 	h.s[3] = h.seed
 }
 
-func (h *hash256) write32(buf []byte) {
-	b := [4]uint64{
-		binary.LittleEndian.Uint64(buf[0:]),
-		binary.LittleEndian.Uint64(buf[8:]),
-		binary.LittleEndian.Uint64(buf[16:]),
-		binary.LittleEndian.Uint64(buf[24:]),
-	}
-	h.s[0] += h.s[1] + b[3]
-	h.s[1] = h.s[0] + bits.RotateLeft64(h.s[1], 14)
-	h.s[2] += h.s[3] + b[2]
-	h.s[3] = h.s[2] + bits.RotateLeft64(h.s[3], 23)
-	h.s[0] += h.s[3] + b[1]
-	h.s[3] = h.s[0] ^ bits.RotateLeft64(h.s[3], 16)
-	h.s[2] += h.s[1] + b[0]
-	h.s[1] = h.s[2] ^ bits.RotateLeft64(h.s[1], 40)
-}
-
 func (h *hash256) Write(buf []byte) (int, error) {
 	total := len(buf)
 	h.total += uint64(total)
@@ -74,16 +73,15 @@ func (h *hash256) Write(buf []byte) (int, error) {
 		h.n += n
 		buf = buf[n:]
 		if h.n == 32 {
-			h.write32(h.block[:])
+			pengyhashCore(&h.s, h.block[:], 1)
 			h.n = 0
 		}
 	}
 
-	for ; len(buf) >= 32; buf = buf[32:] {
-		h.write32(buf)
-		if len(buf) < 64 {
-			copy(h.block[:], buf[:])
-		}
+	if blocks := len(buf) / 32; blocks > 0 {
+		pengyhashCore(&h.s, buf, blocks)
+		copy(h.block[:], buf[(blocks-1)*32:blocks*32])
+		buf = buf[blocks*32:]
 	}
 	h.n = copy(h.block[:], buf[:])
 
@@ -120,24 +118,33 @@ func (h *hash256) Sum(p []byte) []byte {
 }
 
 func (h *hash256) MarshalBinary() ([]byte, error) {
-	var buf [32 + 32 + 8 + 8 + 1]byte
-	copy(buf[0:], h.block[:])
-	binary.LittleEndian.PutUint64(buf[32:], h.s[0])
-	binary.LittleEndian.PutUint64(buf[40:], h.s[1])
-	binary.LittleEndian.PutUint64(buf[48:], h.s[2])
-	binary.LittleEndian.PutUint64(buf[56:], h.s[3])
-	binary.LittleEndian.PutUint64(buf[64:], h.seed)
-	binary.LittleEndian.PutUint64(buf[72:], h.total)
-	buf[80] = byte(h.n)
-	return buf[:], nil
+	return h.AppendBinary(make([]byte, 0, marshaledSize256))
+}
+
+func (h *hash256) AppendBinary(b []byte) ([]byte, error) {
+	b = append(b, magic256...)
+	b = append(b, h.block[:]...)
+	b = binary.LittleEndian.AppendUint64(b, h.s[0])
+	b = binary.LittleEndian.AppendUint64(b, h.s[1])
+	b = binary.LittleEndian.AppendUint64(b, h.s[2])
+	b = binary.LittleEndian.AppendUint64(b, h.s[3])
+	b = binary.LittleEndian.AppendUint64(b, h.seed)
+	b = binary.LittleEndian.AppendUint64(b, h.total)
+	b = append(b, byte(h.n))
+	return b, nil
 }
 
 func (h *hash256) UnmarshalBinary(data []byte) error {
-	if len(data) < 32+32+8+8+1 {
-		return errors.New("invalid length")
+	if len(data) < len(magic256) || string(data[:len(magic256)]) != magic256 {
+		return errors.New("pengyhash: invalid hash state identifier")
 	}
+	if len(data) != marshaledSize256 {
+		return errors.New("pengyhash: invalid hash state size")
+	}
+	data = data[len(magic256):]
+
 	if data[80] >= 32 {
-		return errors.New("invalid data")
+		return errors.New("pengyhash: invalid hash state")
 	}
 
 	copy(h.block[:], data[0:])
@@ -152,36 +159,111 @@ func (h *hash256) UnmarshalBinary(data []byte) error {
 	return nil
 }
 
+// Size64 is the byte length of a pengyhash64 digest.
+const Size64 = 8
+
+type hash64 struct {
+	buf  []byte
+	seed uint32
+}
+
+// New64 returns a new, seeded hash.Hash64 whose Sum64 reproduces the output
+// of Pengyhash for the same bytes and seed. The reference algorithm folds
+// the total input length into its state before mixing in any data, so
+// matching it exactly requires the written bytes to be held until Sum64 (or
+// Sum) is called, rather than being mixed in block-by-block as they arrive.
+//
+// As a result, the hash returned by New64 holds every written byte in
+// memory: its memory use is O(total bytes written), not O(block size). It
+// is unsuitable for hashing large or streamed inputs; use New or
+// NewParallel for those instead.
+//
+// Also implements encoding.BinaryMarshaler, encoding.BinaryAppender, and
+// encoding.BinaryUnmarshaler to marshal and unmarshal the internal state of
+// the hash.
+func New64(seed uint32) hash.Hash64 {
+	return &hash64{seed: seed}
+}
+
+func (h *hash64) Size() int {
+	return Size64
+}
+
+func (h *hash64) BlockSize() int {
+	return BlockSize
+}
+
+func (h *hash64) Reset() {
+	h.buf = h.buf[:0]
+}
+
+func (h *hash64) Write(buf []byte) (int, error) {
+	h.buf = append(h.buf, buf...)
+	return len(buf), nil
+}
+
+func (h *hash64) Sum64() uint64 {
+	return Pengyhash(h.buf, h.seed)
+}
+
+func (h *hash64) Sum(p []byte) []byte {
+	var r [8]byte
+	binary.BigEndian.PutUint64(r[:], h.Sum64())
+	return append(p, r[:]...)
+}
+
+func (h *hash64) MarshalBinary() ([]byte, error) {
+	return h.AppendBinary(make([]byte, 0, marshaledSize64+len(h.buf)))
+}
+
+func (h *hash64) AppendBinary(b []byte) ([]byte, error) {
+	b = append(b, magic64...)
+	b = binary.LittleEndian.AppendUint32(b, h.seed)
+	b = binary.LittleEndian.AppendUint64(b, uint64(len(h.buf)))
+	b = append(b, h.buf...)
+	return b, nil
+}
+
+func (h *hash64) UnmarshalBinary(data []byte) error {
+	if len(data) < len(magic64) || string(data[:len(magic64)]) != magic64 {
+		return errors.New("pengyhash: invalid hash state identifier")
+	}
+	data = data[len(magic64):]
+	if len(data) < marshaledSize64-len(magic64) {
+		return errors.New("pengyhash: invalid hash state size")
+	}
+
+	seed := binary.LittleEndian.Uint32(data[0:])
+	n := binary.LittleEndian.Uint64(data[4:])
+	data = data[12:]
+	if n > uint64(len(data)) {
+		return errors.New("pengyhash: invalid hash state")
+	}
+	h.seed = seed
+	h.buf = append(h.buf[:0], data[:n]...)
+	return nil
+}
+
 // Pengyhash computes the original, non-incremental hash.
 func Pengyhash(buf []byte, seed uint32) uint64 {
-	b := [4]uint64{}
 	s := [4]uint64{0, 0, 0, uint64(len(buf))}
 
-	for ; len(buf) >= 32; buf = buf[32:] {
-		b[0] = binary.LittleEndian.Uint64(buf[0:])
-		b[1] = binary.LittleEndian.Uint64(buf[8:])
-		b[2] = binary.LittleEndian.Uint64(buf[16:])
-		b[3] = binary.LittleEndian.Uint64(buf[24:])
-		s[0] += s[1] + b[3]
-		s[1] = s[0] + bits.RotateLeft64(s[1], 14)
-		s[2] += s[3] + b[2]
-		s[3] = s[2] + bits.RotateLeft64(s[3], 23)
-		s[0] += s[3] + b[1]
-		s[3] = s[0] ^ bits.RotateLeft64(s[3], 16)
-		s[2] += s[1] + b[0]
-		s[1] = s[2] ^ bits.RotateLeft64(s[1], 40)
+	var last []byte
+	if blocks := len(buf) / 32; blocks > 0 {
+		pengyhashCore(&s, buf, blocks)
+		last = buf[(blocks-1)*32 : blocks*32]
+		buf = buf[blocks*32:]
 	}
 
 	var tmp [32]byte
-	binary.LittleEndian.PutUint64(tmp[0:], b[0])
-	binary.LittleEndian.PutUint64(tmp[8:], b[1])
-	binary.LittleEndian.PutUint64(tmp[16:], b[2])
-	binary.LittleEndian.PutUint64(tmp[24:], b[3])
+	copy(tmp[:], last)
 	copy(tmp[:], buf[:])
-	b[0] = binary.LittleEndian.Uint64(tmp[0:])
-	b[1] = binary.LittleEndian.Uint64(tmp[8:])
-	b[2] = binary.LittleEndian.Uint64(tmp[16:])
-	b[3] = binary.LittleEndian.Uint64(tmp[24:])
+	b := [4]uint64{
+		binary.LittleEndian.Uint64(tmp[0:]),
+		binary.LittleEndian.Uint64(tmp[8:]),
+		binary.LittleEndian.Uint64(tmp[16:]),
+		binary.LittleEndian.Uint64(tmp[24:]),
+	}
 
 	for i := 0; i < 6; i++ {
 		s[0] += s[1] + b[3]
@@ -0,0 +1,24 @@
+//go:build amd64 && !purego
+
+package pengyhash
+
+// pengyhashCoreAsm is implemented in pengyhash_amd64.s. It uses only
+// general-purpose integer instructions, so no runtime CPU feature check is
+// needed to call it.
+//
+// Note that the write32 permutation carries state between lanes within a
+// single block (e.g. s[1]'s update depends on the new s[0]) and between
+// successive blocks, so the four lanes cannot be processed independently in
+// SIMD registers. The measurable win from the assembly core is removing the
+// Go call and slice bounds-check overhead around each block's eight
+// add/rotate/xor operations, not vector-width parallelism.
+//
+//go:noescape
+func pengyhashCoreAsm(state *[4]uint64, block []byte, blocks int)
+
+func pengyhashCore(state *[4]uint64, block []byte, blocks int) {
+	if blocks == 0 {
+		return
+	}
+	pengyhashCoreAsm(state, block, blocks)
+}
@@ -0,0 +1,19 @@
+//go:build arm64 && !purego
+
+package pengyhash
+
+// pengyhashCoreAsm is implemented in pengyhash_arm64.s. NEON is not needed:
+// see the amd64 comment in pengyhash_amd64.go for why the write32
+// permutation's lane dependencies rule out vector-width parallelism here.
+// The assembly core instead avoids Go's call and slice bounds-check
+// overhead around each block's eight add/rotate/xor operations.
+//
+//go:noescape
+func pengyhashCoreAsm(state *[4]uint64, block []byte, blocks int)
+
+func pengyhashCore(state *[4]uint64, block []byte, blocks int) {
+	if blocks == 0 {
+		return
+	}
+	pengyhashCoreAsm(state, block, blocks)
+}
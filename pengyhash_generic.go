@@ -0,0 +1,34 @@
+//go:build (!amd64 && !arm64) || purego
+
+package pengyhash
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// pengyhashCore runs the write32 permutation over blocks full 32-byte
+// chunks of block, updating state in place. It is the portable fallback
+// used on architectures without a dedicated assembly implementation, or
+// when built with the purego build tag.
+func pengyhashCore(state *[4]uint64, block []byte, blocks int) {
+	s := *state
+	for i := 0; i < blocks; i++ {
+		b := [4]uint64{
+			binary.LittleEndian.Uint64(block[0:]),
+			binary.LittleEndian.Uint64(block[8:]),
+			binary.LittleEndian.Uint64(block[16:]),
+			binary.LittleEndian.Uint64(block[24:]),
+		}
+		s[0] += s[1] + b[3]
+		s[1] = s[0] + bits.RotateLeft64(s[1], 14)
+		s[2] += s[3] + b[2]
+		s[3] = s[2] + bits.RotateLeft64(s[3], 23)
+		s[0] += s[3] + b[1]
+		s[3] = s[0] ^ bits.RotateLeft64(s[3], 16)
+		s[2] += s[1] + b[0]
+		s[1] = s[2] ^ bits.RotateLeft64(s[1], 40)
+		block = block[32:]
+	}
+	*state = s
+}
@@ -0,0 +1,225 @@
+package pengyhash
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash"
+	"sync"
+)
+
+type hashParallel struct {
+	seed    uint64
+	chunk   int
+	workers int
+
+	sem     chan struct{}
+	wg      sync.WaitGroup
+	mu      sync.Mutex
+	digests [][Size]byte // completed chunk digests, in order
+
+	pending []byte // bytes of the in-progress, not-yet-dispatched chunk
+	total   uint64
+}
+
+// NewParallel returns a hash.Hash that splits its input into chunk-byte
+// pieces and hashes each piece concurrently across up to workers
+// goroutines using the 256-bit incremental algorithm, then combines the
+// per-chunk digests with a final pengyhash256 pass over the concatenated
+// digests, their indices, and the total input length. The result depends
+// only on chunk and the input bytes, not on workers, so it is reproducible
+// regardless of how many cores computed it.
+//
+// Each chunk is dispatched to a worker as soon as Write has accumulated it,
+// so large inputs never need to be held in memory in full.
+//
+// This is a distinct algorithm from New, with its own digest space: hashing
+// the same bytes with New and NewParallel produces different results.
+func NewParallel(seed uint64, chunk, workers int) hash.Hash {
+	if chunk <= 0 {
+		panic("pengyhash: chunk must be positive")
+	}
+	if workers < 1 {
+		workers = 1
+	}
+	return &hashParallel{
+		seed:    seed,
+		chunk:   chunk,
+		workers: workers,
+		sem:     make(chan struct{}, workers),
+		pending: make([]byte, 0, chunk),
+	}
+}
+
+func (h *hashParallel) Size() int {
+	return Size
+}
+
+func (h *hashParallel) BlockSize() int {
+	return h.chunk
+}
+
+func (h *hashParallel) Reset() {
+	h.wg.Wait()
+	h.digests = h.digests[:0]
+	h.pending = h.pending[:0]
+	h.total = 0
+}
+
+func (h *hashParallel) Write(buf []byte) (int, error) {
+	total := len(buf)
+	h.total += uint64(total)
+
+	for len(buf) > 0 {
+		n := h.chunk - len(h.pending)
+		if n > len(buf) {
+			n = len(buf)
+		}
+		h.pending = append(h.pending, buf[:n]...)
+		buf = buf[n:]
+
+		if len(h.pending) == h.chunk {
+			h.dispatch(h.pending)
+			h.pending = make([]byte, 0, h.chunk)
+		}
+	}
+
+	return total, nil
+}
+
+// dispatch hands data, a completed chunk, to a worker goroutine bounded by
+// h.sem, freeing the caller to reuse or discard data's backing array
+// immediately: dispatch keeps its own copy.
+func (h *hashParallel) dispatch(data []byte) {
+	owned := append([]byte(nil), data...)
+
+	h.mu.Lock()
+	idx := len(h.digests)
+	h.digests = append(h.digests, [Size]byte{})
+	h.mu.Unlock()
+
+	h.wg.Add(1)
+	h.sem <- struct{}{}
+	go func() {
+		defer h.wg.Done()
+		defer func() { <-h.sem }()
+
+		ch := New(h.seed)
+		ch.Write(owned)
+		sum := ch.Sum(nil)
+
+		h.mu.Lock()
+		copy(h.digests[idx][:], sum)
+		h.mu.Unlock()
+	}()
+}
+
+// reduce combines digests (one Size-byte digest per chunk, in chunk order)
+// and the total input length seen so far into the final pengyhash256 pass.
+func (h *hashParallel) reduce(digests [][Size]byte) []byte {
+	final := New(h.seed)
+	var idx [8]byte
+	for i, d := range digests {
+		final.Write(d[:])
+		binary.LittleEndian.PutUint64(idx[:], uint64(i))
+		final.Write(idx[:])
+	}
+	var total [8]byte
+	binary.LittleEndian.PutUint64(total[:], h.total)
+	final.Write(total[:])
+	return final.Sum(nil)
+}
+
+func (h *hashParallel) Sum(p []byte) []byte {
+	h.wg.Wait()
+
+	h.mu.Lock()
+	digests := append([][Size]byte(nil), h.digests...)
+	h.mu.Unlock()
+
+	// The in-progress chunk is folded in as the final chunk without being
+	// dispatched, so Sum leaves the hash's state unchanged and further
+	// Writes can still extend it.
+	if len(h.pending) > 0 {
+		ch := New(h.seed)
+		ch.Write(h.pending)
+		var d [Size]byte
+		copy(d[:], ch.Sum(nil))
+		digests = append(digests, d)
+	}
+
+	return append(p, h.reduce(digests)...)
+}
+
+func (h *hashParallel) MarshalBinary() ([]byte, error) {
+	h.wg.Wait()
+	return h.AppendBinary(make([]byte, 0, len(magicParallel)+8*5+len(h.digests)*Size+len(h.pending)))
+}
+
+func (h *hashParallel) AppendBinary(b []byte) ([]byte, error) {
+	h.wg.Wait()
+
+	b = append(b, magicParallel...)
+	b = binary.LittleEndian.AppendUint64(b, h.seed)
+	b = binary.LittleEndian.AppendUint64(b, uint64(h.chunk))
+	b = binary.LittleEndian.AppendUint64(b, uint64(h.workers))
+	b = binary.LittleEndian.AppendUint64(b, h.total)
+
+	h.mu.Lock()
+	b = binary.LittleEndian.AppendUint64(b, uint64(len(h.digests)))
+	for _, d := range h.digests {
+		b = append(b, d[:]...)
+	}
+	h.mu.Unlock()
+
+	b = binary.LittleEndian.AppendUint64(b, uint64(len(h.pending)))
+	b = append(b, h.pending...)
+	return b, nil
+}
+
+func (h *hashParallel) UnmarshalBinary(data []byte) error {
+	if len(data) < len(magicParallel) || string(data[:len(magicParallel)]) != magicParallel {
+		return errors.New("pengyhash: invalid hash state identifier")
+	}
+	data = data[len(magicParallel):]
+	if len(data) < 8*5 {
+		return errors.New("pengyhash: invalid hash state size")
+	}
+
+	seed := binary.LittleEndian.Uint64(data[0:])
+	chunk := binary.LittleEndian.Uint64(data[8:])
+	workers := binary.LittleEndian.Uint64(data[16:])
+	total := binary.LittleEndian.Uint64(data[24:])
+	nDigests := binary.LittleEndian.Uint64(data[32:])
+	data = data[40:]
+
+	if int64(chunk) <= 0 || int64(workers) <= 0 {
+		return errors.New("pengyhash: invalid hash state")
+	}
+	if nDigests > uint64(len(data))/Size {
+		return errors.New("pengyhash: invalid hash state")
+	}
+	digests := make([][Size]byte, nDigests)
+	for i := range digests {
+		copy(digests[i][:], data[:Size])
+		data = data[Size:]
+	}
+
+	if len(data) < 8 {
+		return errors.New("pengyhash: invalid hash state size")
+	}
+	nPending := binary.LittleEndian.Uint64(data[0:])
+	data = data[8:]
+	if nPending > uint64(len(data)) || nPending > chunk {
+		return errors.New("pengyhash: invalid hash state")
+	}
+
+	h.wg.Wait()
+	h.seed = seed
+	h.chunk = int(chunk)
+	h.workers = int(workers)
+	h.sem = make(chan struct{}, h.workers)
+	h.total = total
+	h.digests = digests
+	h.pending = append(make([]byte, 0, chunk), data[:nPending]...)
+	return nil
+}
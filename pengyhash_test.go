@@ -3,6 +3,7 @@ package pengyhash
 import (
 	"bytes"
 	"encoding"
+	"encoding/binary"
 	"testing"
 )
 
@@ -32,6 +33,150 @@ func TestOutput(t *testing.T) {
 	}
 }
 
+func TestNew64(t *testing.T) {
+	var input [39]byte
+	for i := range input {
+		input[i] = byte(i)
+	}
+	const seed = 0x12345678
+
+	want := Pengyhash(input[:], seed)
+
+	h := New64(seed)
+	h.Write(input[:20])
+	h.Write(input[20:])
+	if got := h.Sum64(); got != want {
+		t.Errorf("Sum64(), got %016x, want %016x", got, want)
+	}
+
+	sum := h.Sum(nil)
+	if binary.BigEndian.Uint64(sum) != want {
+		t.Errorf("Sum(nil), got %#v, want %016x", sum, want)
+	}
+}
+
+func TestNew64Marshal(t *testing.T) {
+	var buf [1<<10 + 13]byte
+	h0 := New64(1)
+	h0.Write(buf[:])
+	data, _ := h0.(encoding.BinaryMarshaler).MarshalBinary()
+	want := h0.Sum64()
+
+	h1 := New64(0)
+	h1.(encoding.BinaryUnmarshaler).UnmarshalBinary(data)
+	got := h1.Sum64()
+
+	if got != want {
+		t.Errorf("Marshaler got %016x, want %016x", got, want)
+	}
+}
+
+func TestUnmarshalRejectsWrongVariant(t *testing.T) {
+	h256 := New(1)
+	data, _ := h256.(encoding.BinaryMarshaler).MarshalBinary()
+
+	h64 := New64(1)
+	if err := h64.(encoding.BinaryUnmarshaler).UnmarshalBinary(data); err == nil {
+		t.Error("UnmarshalBinary of a pengyhash256 state into a pengyhash64 hash, got nil error, want error")
+	}
+}
+
+func TestAppendBinary(t *testing.T) {
+	h := New(1)
+	h.Write([]byte("hello"))
+
+	prefix := []byte("prefix")
+	got, _ := h.(interface {
+		AppendBinary([]byte) ([]byte, error)
+	}).AppendBinary(prefix)
+	if !bytes.HasPrefix(got, prefix) {
+		t.Errorf("AppendBinary(prefix) did not preserve prefix, got %#v", got)
+	}
+
+	want, _ := h.(encoding.BinaryMarshaler).MarshalBinary()
+	if !bytes.Equal(got[len(prefix):], want) {
+		t.Errorf("AppendBinary(prefix), got %#v, want %#v", got[len(prefix):], want)
+	}
+}
+
+func TestParallelWorkerInvariant(t *testing.T) {
+	buf := make([]byte, 10_000)
+	for i := range buf {
+		buf[i] = byte(i)
+	}
+
+	const seed, chunk = 7, 1024
+	var want []byte
+	for _, workers := range []int{1, 2, 3, 8, 64} {
+		h := NewParallel(seed, chunk, workers)
+		h.Write(buf[:4000])
+		h.Write(buf[4000:])
+		got := h.Sum(nil)
+		if want == nil {
+			want = got
+			continue
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("workers=%d: Sum(), got %#v, want %#v", workers, got, want)
+		}
+	}
+}
+
+func TestParallelDistinctFromNew(t *testing.T) {
+	buf := make([]byte, 100)
+
+	h := NewParallel(1, 32, 4)
+	h.Write(buf)
+	got := h.Sum(nil)
+
+	h256 := New(1)
+	h256.Write(buf)
+	want := h256.Sum(nil)
+
+	if bytes.Equal(got, want) {
+		t.Error("NewParallel produced the same digest as New for the same bytes and seed")
+	}
+}
+
+func TestParallelMarshal(t *testing.T) {
+	buf := make([]byte, 5000)
+
+	h0 := NewParallel(1, 512, 4)
+	h0.Write(buf)
+	data, _ := h0.(encoding.BinaryMarshaler).MarshalBinary()
+	want := h0.Sum(nil)
+
+	h1 := NewParallel(0, 1, 1)
+	h1.(encoding.BinaryUnmarshaler).UnmarshalBinary(data)
+	got := h1.Sum(nil)
+
+	if !bytes.Equal(want, got) {
+		t.Errorf("Marshaler got %#v, want %#v", got, want)
+	}
+}
+
+func TestParallelUnmarshalRejectsInvalidChunkOrWorkers(t *testing.T) {
+	h0 := NewParallel(1, 512, 4)
+	h0.Write(make([]byte, 5000))
+	data, _ := h0.(encoding.BinaryMarshaler).MarshalBinary()
+
+	// Corrupt the chunk field (first uint64 after the magic and seed).
+	bad := append([]byte(nil), data...)
+	binary.LittleEndian.PutUint64(bad[len(magicParallel)+8:], 0)
+	h1 := NewParallel(1, 1, 1)
+	if err := h1.(encoding.BinaryUnmarshaler).UnmarshalBinary(bad); err == nil {
+		t.Error("UnmarshalBinary with chunk=0, got nil error, want error")
+	}
+
+	// Corrupt the workers field (second uint64 after the magic and seed).
+	bad = append([]byte(nil), data...)
+	binary.LittleEndian.PutUint64(bad[len(magicParallel)+16:], 0)
+	h2 := NewParallel(1, 1, 1)
+	if err := h2.(encoding.BinaryUnmarshaler).UnmarshalBinary(bad); err == nil {
+		t.Error("UnmarshalBinary with workers=0, got nil error, want error")
+	}
+}
+
 func TestMarshal(t *testing.T) {
 	var zero [1<<20 + 31]byte
 	h0 := New(1)